@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// mergeEvent records one step of an agglomerative merge: the two (leaf or previously merged)
+// node IDs that were joined, the distance at which they were joined, and the resulting
+// cluster's size. LeftID/RightID refer to leaf node IDs (0..leafCount-1, one per input label,
+// in order of first appearance) or to earlier merge nodes (leafCount+StepID of that event).
+type mergeEvent struct {
+	StepID  int
+	LeftID  int
+	RightID int
+	Height  float64
+	Size    int
+}
+
+// recordMerge appends a merge event joining leftID and rightID at the given height, records the
+// combined leaf labels under the new node's ID in nodeLabels, and returns that ID so the caller
+// can use it as an endpoint of a later merge. New node IDs continue on from the leaves, i.e.
+// leafCount+StepID of the event just appended.
+func recordMerge(events *[]mergeEvent, leftID, rightID int, height float64, nodeLabels map[int][]string, leafCount int, size int) int {
+	stepID := len(*events)
+	nodeID := leafCount + stepID
+	*events = append(*events, mergeEvent{StepID: stepID, LeftID: leftID, RightID: rightID, Height: height, Size: size})
+
+	labels := make([]string, 0, size)
+	labels = append(labels, nodeLabels[leftID]...)
+	labels = append(labels, nodeLabels[rightID]...)
+	nodeLabels[nodeID] = labels
+
+	return nodeID
+}
+
+// deriveFlatClusters truncates a merge tree at the cutoff: only merges whose height clears the
+// cutoff (per the `--includeequal` rule) are applied, so users can re-cut the same tree at a
+// different threshold without re-reading the input. nodeLabels maps every leaf and merge node ID
+// to the leaf labels beneath it; leafCount is the number of original labels (node IDs below it
+// are leaves).
+func deriveFlatClusters(events []mergeEvent, nodeLabels map[int][]string, leafCount int, cutOff float64, includeEqual bool) []clusterInfo {
+	clustersID := make(map[string]int)
+	clusterMembers := make(map[int]map[string]bool)
+	numClusters := 0
+
+	for _, e := range events {
+		if includeEqual && e.Height > cutOff || !includeEqual && e.Height >= cutOff {
+			continue
+		}
+
+		labels := make([]string, 0, len(nodeLabels[e.LeftID])+len(nodeLabels[e.RightID]))
+		labels = append(labels, nodeLabels[e.LeftID]...)
+		labels = append(labels, nodeLabels[e.RightID]...)
+
+		existingIDs := make(map[int]bool)
+		for _, label := range labels {
+			if id, ok := clustersID[label]; ok {
+				existingIDs[id] = true
+			}
+		}
+
+		keep := -1
+		for id := range existingIDs {
+			if keep == -1 || id < keep {
+				keep = id
+			}
+		}
+		if keep == -1 {
+			keep = numClusters
+			numClusters++
+			clusterMembers[keep] = make(map[string]bool)
+		}
+
+		for id := range existingIDs {
+			if id == keep {
+				continue
+			}
+			for member := range clusterMembers[id] {
+				clustersID[member] = keep
+				clusterMembers[keep][member] = true
+			}
+			delete(clusterMembers, id)
+		}
+		for _, label := range labels {
+			clustersID[label] = keep
+			clusterMembers[keep][label] = true
+		}
+	}
+
+	// Leaves untouched by any qualifying merge still form their own singleton cluster.
+	for id := 0; id < leafCount; id++ {
+		for _, label := range nodeLabels[id] {
+			if _, ok := clustersID[label]; !ok {
+				clustersID[label] = numClusters
+				clusterMembers[numClusters] = map[string]bool{label: true}
+				numClusters++
+			}
+		}
+	}
+
+	clusters := buildClusterInfo(clusterMembers, clustersID)
+	return reassignClusterIDs(clusters)
+}
+
+// buildNewickForest renders a merge history as Newick trees, one per connected component
+// (a single input rarely connects every label, so the result is usually a forest rather than
+// one tree). Branch lengths are the difference between a node's merge height and its child's.
+func buildNewickForest(labels []string, events []mergeEvent) []string {
+	leafCount := len(labels)
+
+	newickOf := make(map[int]string, leafCount+len(events))
+	heightOf := make(map[int]float64, leafCount+len(events))
+	minLabelOf := make(map[int]string, leafCount+len(events))
+	isRoot := make(map[int]bool, leafCount+len(events))
+	for i, label := range labels {
+		newickOf[i] = label
+		heightOf[i] = 0
+		minLabelOf[i] = label
+		isRoot[i] = true
+	}
+
+	for _, e := range events {
+		nodeID := leafCount + e.StepID
+		leftBranch := e.Height - heightOf[e.LeftID]
+		rightBranch := e.Height - heightOf[e.RightID]
+		newickOf[nodeID] = fmt.Sprintf("(%s:%g,%s:%g)", newickOf[e.LeftID], leftBranch, newickOf[e.RightID], rightBranch)
+		heightOf[nodeID] = e.Height
+		minLabelOf[nodeID] = minLabelOf[e.LeftID]
+		if minLabelOf[e.RightID] < minLabelOf[nodeID] {
+			minLabelOf[nodeID] = minLabelOf[e.RightID]
+		}
+		isRoot[nodeID] = true
+		delete(isRoot, e.LeftID)
+		delete(isRoot, e.RightID)
+	}
+
+	roots := make([]int, 0, len(isRoot))
+	for id := range isRoot {
+		roots = append(roots, id)
+	}
+	// Map iteration order is randomized, which would otherwise make --dendrogram output for the
+	// same input differ across runs. Sort roots by their smallest leaf label for a stable,
+	// reproducible tree order.
+	sort.Slice(roots, func(i, j int) bool { return minLabelOf[roots[i]] < minLabelOf[roots[j]] })
+
+	trees := make([]string, 0, len(roots))
+	for _, id := range roots {
+		trees = append(trees, newickOf[id]+";")
+	}
+	return trees
+}
+
+// writeDendrogram writes the full agglomerative merge tree in Newick format, one tree per
+// connected component, to outputPath.
+func writeDendrogram(outputPath string, labels []string, events []mergeEvent) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, tree := range buildNewickForest(labels, events) {
+		if _, err := fmt.Fprintln(writer, tree); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// writeHeights dumps every merge step as (step, height, left node ID, right node ID, resulting
+// size) so the merge history can be inspected or re-cut without rerunning the clustering.
+func writeHeights(outputPath string, events []mergeEvent) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, e := range events {
+		if _, err := fmt.Fprintf(writer, "%d\t%g\t%d\t%d\t%d\n", e.StepID, e.Height, e.LeftID, e.RightID, e.Size); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGetCompleteLinkageClustersMergeHeightsMonotonic guards against reintroducing the bug
+// where complete linkage processed pairs in file-arrival order instead of ascending distance,
+// which could record a merge event at a height lower than one of its own children's heights and
+// produce a negative Newick branch length.
+func TestGetCompleteLinkageClustersMergeHeightsMonotonic(t *testing.T) {
+	path := writeTempPairs(t, "A B 1\nC D 1\nA E 0.5\n")
+
+	_, events, labels, err := getCompleteLinkageClusters(path, 2, true)
+	if err != nil {
+		t.Fatalf("getCompleteLinkageClusters: %v", err)
+	}
+
+	leafCount := len(labels)
+	heightOf := make(map[int]float64, leafCount+len(events))
+	for i := range labels {
+		heightOf[i] = 0
+	}
+	for _, e := range events {
+		if e.Height < heightOf[e.LeftID] || e.Height < heightOf[e.RightID] {
+			t.Fatalf("merge event %+v has height below a child's height (left=%g, right=%g)",
+				e, heightOf[e.LeftID], heightOf[e.RightID])
+		}
+		heightOf[leafCount+e.StepID] = e.Height
+	}
+
+	for _, tree := range buildNewickForest(labels, events) {
+		if strings.Contains(tree, ":-") {
+			t.Errorf("Newick tree has a negative branch length: %s", tree)
+		}
+	}
+}
+
+// TestBuildNewickForestOrderIsDeterministic guards against reintroducing the bug where the
+// forest's tree order came from ranging over a map, which Go randomizes per-process. With a
+// disconnected input (several singleton/small roots), the returned order must be stable across
+// repeated calls and sorted by each root's smallest leaf label.
+func TestBuildNewickForestOrderIsDeterministic(t *testing.T) {
+	path := writeTempPairs(t, "Z Y 1\nB A 1\nD C 1\n")
+
+	_, events, labels, err := getCompleteLinkageClusters(path, 0.5, true)
+	if err != nil {
+		t.Fatalf("getCompleteLinkageClusters: %v", err)
+	}
+
+	want := buildNewickForest(labels, events)
+	for i := 0; i < 20; i++ {
+		got := buildNewickForest(labels, events)
+		if len(got) != len(want) {
+			t.Fatalf("run %d: got %d trees, want %d", i, len(got), len(want))
+		}
+		for j := range want {
+			if got[j] != want[j] {
+				t.Fatalf("run %d: tree order is not deterministic: got %v, want %v", i, got, want)
+			}
+		}
+	}
+
+	// Roots are expected in ascending order of their smallest leaf label: {A,B} (min "A"),
+	// {C,D} (min "C"), then {Y,Z} (min "Y").
+	if len(want) != 3 ||
+		!strings.Contains(want[0], "A") || !strings.Contains(want[0], "B") ||
+		!strings.Contains(want[1], "C") || !strings.Contains(want[1], "D") ||
+		!strings.Contains(want[2], "Y") || !strings.Contains(want[2], "Z") {
+		t.Errorf("expected trees ordered [{A,B}, {C,D}, {Y,Z}] by smallest leaf label, got %v", want)
+	}
+}
+
+func TestWriteDendrogramAndHeights(t *testing.T) {
+	path := writeTempPairs(t, "A B 1\nB C 2\n")
+
+	clusters, events, labels, err := getSingleLinkageClusters(path, 10, true)
+	if err != nil {
+		t.Fatalf("getSingleLinkageClusters: %v", err)
+	}
+	if len(clusters) != 1 {
+		t.Fatalf("expected a single cluster under a generous cutoff, got %d", len(clusters))
+	}
+
+	dendroPath := t.TempDir() + "/dendro.nwk"
+	if err := writeDendrogram(dendroPath, labels, events); err != nil {
+		t.Fatalf("writeDendrogram: %v", err)
+	}
+	dendro, err := os.ReadFile(dendroPath)
+	if err != nil {
+		t.Fatalf("reading dendrogram: %v", err)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(string(dendro)), ";") {
+		t.Errorf("expected a Newick tree terminated with ';', got %q", dendro)
+	}
+
+	heightsPath := t.TempDir() + "/heights.tsv"
+	if err := writeHeights(heightsPath, events); err != nil {
+		t.Fatalf("writeHeights: %v", err)
+	}
+	heights, err := os.ReadFile(heightsPath)
+	if err != nil {
+		t.Fatalf("reading heights: %v", err)
+	}
+	if len(strings.Split(strings.TrimSpace(string(heights)), "\n")) != len(events) {
+		t.Errorf("expected one heights row per merge event")
+	}
+}
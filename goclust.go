@@ -9,6 +9,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/vmikk/goclust/internal/unionfind"
 )
 
 // clusterInfo holds information about a single cluster
@@ -18,146 +20,172 @@ type clusterInfo struct {
 }
 
 // There are two clustering functions - `getSingleLinkageClusters` and `getCompleteLinkageClusters`
-// They read pairwise distances from the input file,
-// form clusters based on the cutoff distance,
-// and return cluster members and their IDs
-
-// Single linkage clustering
-func getSingleLinkageClusters(inputPath string, cutOff float64, includeEqual bool) ([]clusterInfo, error) {
-	clustersID := make(map[string]int)
-	clusterMembers := make(map[int]map[string]bool)
-	labelsSet := make(map[string]bool)
+// They read pairwise distances from the input file, build the full agglomerative merge tree,
+// and return both the cutoff-truncated flat clusters and the merge history behind them so the
+// same tree can be re-cut at another threshold, or rendered as a dendrogram, without rereading
+// the input.
+
+// labeledPair is one label1/label2/distance line from a pairwise-distance input file.
+type labeledPair struct {
+	label1, label2 string
+	distance       float64
+}
 
-	numClusters := 0
+// readLabeledPairs reads a label1/label2/distance stream, returning every valid pair together
+// with the set of labels in order of first appearance, so a label that never pairs with
+// anything below the cutoff isn't lost - it just ends up as its own singleton cluster.
+func readLabeledPairs(inputPath string) ([]string, []labeledPair, error) {
 	file, err := os.Open(inputPath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer file.Close()
 
+	seen := make(map[string]bool)
+	var labels []string
+	var pairs []labeledPair
+
+	intern := func(label string) {
+		if !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Fields(line)
+		parts := strings.Fields(scanner.Text())
 		if len(parts) < 3 {
 			continue // Skip lines that don't have enough parts
 		}
-		label1, label2, distanceStr := parts[0], parts[1], parts[2]
-
-		labelsSet[label1] = true
-		labelsSet[label2] = true
 
-		distance, err := strconv.ParseFloat(distanceStr, 64)
+		distance, err := strconv.ParseFloat(parts[2], 64)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		// Comparison with the cutoff depends on the `--includeequal` flag
-		if includeEqual && distance > cutOff || !includeEqual && distance >= cutOff {
+		intern(parts[0])
+		intern(parts[1])
+		if parts[0] == parts[1] {
 			continue
 		}
 
-		in1, ok1 := clustersID[label1]
-		in2, ok2 := clustersID[label2]
+		pairs = append(pairs, labeledPair{label1: parts[0], label2: parts[1], distance: distance})
+	}
 
-		if !ok1 && !ok2 {
-			clusterID := numClusters
-			clustersID[label1] = clusterID
-			clustersID[label2] = clusterID
-			clusterMembers[clusterID] = map[string]bool{label1: true, label2: true}
-			numClusters++
-		} else if ok1 && !ok2 {
-			clustersID[label2] = in1
-			clusterMembers[in1][label2] = true
-		} else if !ok1 && ok2 {
-			clustersID[label1] = in2
-			clusterMembers[in2][label1] = true
-		} else if in1 != in2 {
-			for label := range clusterMembers[in2] {
-				clustersID[label] = in1
-				clusterMembers[in1][label] = true
-			}
-			delete(clusterMembers, in2)
-		}
+	return labels, pairs, scanner.Err()
+}
+
+// Single linkage clustering, built as Kruskal's algorithm over edges sorted by ascending
+// distance: each edge that joins two different components is a merge event, which is exactly a
+// single-linkage dendrogram. Components are tracked with a weighted union-find (see
+// internal/unionfind) instead of relabeling a map[string]int on every merge, so this scales to
+// multi-million-edge inputs.
+func getSingleLinkageClusters(inputPath string, cutOff float64, includeEqual bool) ([]clusterInfo, []mergeEvent, []string, error) {
+	labels, pairs, err := readLabeledPairs(inputPath)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].distance < pairs[j].distance })
 
-	// Build initial clusterInfo slice from clusterMembers
-    initialClusters := buildClusterInfo(clusterMembers, clustersID)
+	uf := unionfind.New()
+	nodeLabels := make(map[int][]string, len(labels)*2)
+	for i, label := range labels {
+		uf.Intern(label)
+		nodeLabels[i] = []string{label}
+	}
+	nodeID := make([]int, len(labels))
+	for i := range nodeID {
+		nodeID[i] = i
+	}
 
-	// Reassign cluster IDs to be zero-based and sequential
-	sequentialClusters := reassignClusterIDs(initialClusters)
+	var events []mergeEvent
+	for _, p := range pairs {
+		a, b := uf.Find(uf.Intern(p.label1)), uf.Find(uf.Intern(p.label2))
+		if a == b {
+			continue
+		}
 
-	return sequentialClusters, nil
-}
+		merged := recordMerge(&events, nodeID[a], nodeID[b], p.distance, nodeLabels, len(labels), int(uf.Size(a)+uf.Size(b)))
 
-// Complete linkage clustering
-func getCompleteLinkageClusters(inputPath string, cutOff float64, includeEqual bool) ([]clusterInfo, error) {
-	clustersID := make(map[string]int)
-	clusterMembers := make(map[int]map[string]bool)
-	maxDistances := make(map[int]map[int]float64) // track maximum distances between clusters
+		root := uf.Union(a, b)
+		nodeID[root] = merged
+	}
 
-	numClusters := 0
-	file, err := os.Open(inputPath)
+	clusters := deriveFlatClusters(events, nodeLabels, len(labels), cutOff, includeEqual)
+	return clusters, events, labels, nil
+}
+
+// Complete linkage clustering. Every cross-cluster edge that completes the max-distance
+// bookkeeping for a pair of clusters is recorded as a merge event at the larger of that max
+// distance and the edge's own distance; the cutoff is applied afterwards, when
+// deriveFlatClusters truncates the resulting tree. Pairs are processed in ascending distance
+// order - as single linkage already does - so that, together with that larger-of rule, every
+// recorded height is at least as large as the heights of the nodes it joins; without that,
+// maxDistances can still be below an earlier-recorded merge height and buildNewickForest ends up
+// with negative branch lengths.
+func getCompleteLinkageClusters(inputPath string, cutOff float64, includeEqual bool) ([]clusterInfo, []mergeEvent, []string, error) {
+	labels, pairs, err := readLabeledPairs(inputPath)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	defer file.Close()
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].distance < pairs[j].distance })
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Fields(line)
-		if len(parts) < 3 {
-			continue // Skip insufficient data
-		}
-		label1, label2, distanceStr := parts[0], parts[1], parts[2]
+	leafIndex := make(map[string]int, len(labels))
+	nodeLabels := make(map[int][]string, len(labels)*2)
+	for i, label := range labels {
+		leafIndex[label] = i
+		nodeLabels[i] = []string{label}
+	}
 
-		distance, err := strconv.ParseFloat(distanceStr, 64)
-		if err != nil {
-			return nil, err
-		}
+	clustersID := make(map[string]int, len(labels))
+	clusterMembers := make(map[int]map[string]bool)
+	maxDistances := make(map[int]map[int]float64) // track maximum distances between clusters
+	nodeID := make(map[int]int)                    // clusterID -> current merge-tree node ID
 
-		clusterID1, ok1 := clustersID[label1]
-		clusterID2, ok2 := clustersID[label2]
+	numClusters := 0
+	var events []mergeEvent
+
+	for _, p := range pairs {
+		clusterID1, ok1 := clustersID[p.label1]
+		clusterID2, ok2 := clustersID[p.label2]
 
 		if !ok1 && !ok2 {
 			// Both labels are new, create a new cluster
 			clusterID := numClusters
-			clustersID[label1] = clusterID
-			clustersID[label2] = clusterID
-			clusterMembers[clusterID] = map[string]bool{label1: true, label2: true}
 			numClusters++
+			clustersID[p.label1] = clusterID
+			clustersID[p.label2] = clusterID
+			clusterMembers[clusterID] = map[string]bool{p.label1: true, p.label2: true}
 			maxDistances[clusterID] = make(map[int]float64)
+			nodeID[clusterID] = recordMerge(&events, leafIndex[p.label1], leafIndex[p.label2], p.distance, nodeLabels, len(labels), 2)
 		} else if ok1 && !ok2 {
-			clustersID[label2] = clusterID1
-			clusterMembers[clusterID1][label2] = true
-			updateMaxDistancesForNewMember(clusterID1, label2, distance, clusterMembers, maxDistances, cutOff)
+			clustersID[p.label2] = clusterID1
+			clusterMembers[clusterID1][p.label2] = true
+			nodeID[clusterID1] = recordMerge(&events, nodeID[clusterID1], leafIndex[p.label2], p.distance, nodeLabels, len(labels), len(clusterMembers[clusterID1]))
+			updateMaxDistancesForNewMember(clusterID1, p.distance, clusterMembers, maxDistances)
 		} else if !ok1 && ok2 {
-			clustersID[label1] = clusterID2
-			clusterMembers[clusterID2][label1] = true
-			updateMaxDistancesForNewMember(clusterID2, label1, distance, clusterMembers, maxDistances, cutOff)
-		} else if clusterID1 != clusterID2 && distance <= cutOff {
-			if shouldMerge(clusterID1, clusterID2, maxDistances, cutOff) {
+			clustersID[p.label1] = clusterID2
+			clusterMembers[clusterID2][p.label1] = true
+			nodeID[clusterID2] = recordMerge(&events, nodeID[clusterID2], leafIndex[p.label1], p.distance, nodeLabels, len(labels), len(clusterMembers[clusterID2]))
+			updateMaxDistancesForNewMember(clusterID2, p.distance, clusterMembers, maxDistances)
+		} else if clusterID1 != clusterID2 {
+			if maxDistance, exists := maxDistances[clusterID1][clusterID2]; exists {
+				size := len(clusterMembers[clusterID1]) + len(clusterMembers[clusterID2])
+				height := max(maxDistance, p.distance)
+				nodeID[clusterID1] = recordMerge(&events, nodeID[clusterID1], nodeID[clusterID2], height, nodeLabels, len(labels), size)
 				mergeClusters(clusterMembers, clustersID, clusterID1, clusterID2, maxDistances)
+				delete(nodeID, clusterID2)
 			}
 		}
 	}
 
-	clusters := buildClusterInfo(clusterMembers, clustersID)
-	return reassignClusterIDs(clusters), nil
-}
-
-// Helper function to determine if two clusters should merge based on the max distances recorded
-func shouldMerge(clusterID1, clusterID2 int, maxDistances map[int]map[int]float64, cutOff float64) bool {
-	// Check the maximum recorded distance between these two clusters
-	if maxDistance, exists := maxDistances[clusterID1][clusterID2]; exists {
-		return maxDistance <= cutOff
-	}
-	return false
+	clusters := deriveFlatClusters(events, nodeLabels, len(labels), cutOff, includeEqual)
+	return clusters, events, labels, nil
 }
 
 // Update maximum distances when a new member is added to a cluster
-func updateMaxDistancesForNewMember(clusterID int, newLabel string, distance float64, clusterMembers map[int]map[string]bool, maxDistances map[int]map[int]float64, cutOff float64) {
+func updateMaxDistancesForNewMember(clusterID int, distance float64, clusterMembers map[int]map[string]bool, maxDistances map[int]map[int]float64) {
     for otherClusterID := range clusterMembers {
         if otherClusterID != clusterID {
             // Check if there is already a recorded distance between these clusters
@@ -281,7 +309,10 @@ func main() {
 	output := flag.String("output", "", "Path to the output file for cluster assignments")
 	cutoff := flag.Float64("cutoff", 0.0, "Distance cutoff for clustering (must be greater than 0)")
 	includeEqual := flag.Bool("includeequal", true, "Include distances equal to cutoff in clustering (default is true; set it to false for strictly greater than cutoff)")
-	method := flag.String("method", "single", "Clustering method to use ('single' or 'complete')")
+	method := flag.String("method", "single", "Clustering method to use ('single', 'complete', 'average', or 'ward')")
+	informat := flag.String("informat", "pairs", "Input format: 'pairs' (label1 label2 distance), 'phylip-square', or 'phylip-lower'")
+	dendrogram := flag.String("dendrogram", "", "Path to write the full merge tree in Newick format (single/complete methods only)")
+	heights := flag.String("heights", "", "Path to write every merge step as (step, height, left-id, right-id, size) (single/complete methods only)")
 
 	// Parse the command-line flags
 	flag.Parse()
@@ -294,19 +325,57 @@ func main() {
 
 	// fmt.Printf("Using the %s method for clustering.\n", *method)
 
+	clusterInput := *input
+	switch *informat {
+	case "pairs":
+		// Already a label1/label2/distance stream; nothing to convert.
+	case "phylip-square", "phylip-lower":
+		tmpPath, err := convertPhylipToPairs(*input, *informat == "phylip-lower")
+		if err != nil {
+			log.Fatalf("Error reading PHYLIP input: %v", err)
+		}
+		defer os.Remove(tmpPath)
+		clusterInput = tmpPath
+	default:
+		log.Fatalf("Unknown --informat value: %s", *informat)
+	}
+
 	var clusters []clusterInfo
+	var events []mergeEvent
+	var labels []string
 	var err error
 
-	if *method == "single" {
-		clusters, err = getSingleLinkageClusters(*input, *cutoff, *includeEqual)
-	} else {
-		clusters, err = getCompleteLinkageClusters(*input, *cutoff, *includeEqual)
+	switch *method {
+	case "single":
+		clusters, events, labels, err = getSingleLinkageClusters(clusterInput, *cutoff, *includeEqual)
+	case "average":
+		clusters, err = getAverageLinkageClusters(clusterInput, *cutoff, *includeEqual)
+	case "ward":
+		clusters, err = getWardLinkageClusters(clusterInput, *cutoff, *includeEqual)
+	default:
+		clusters, events, labels, err = getCompleteLinkageClusters(clusterInput, *cutoff, *includeEqual)
 	}
 
 	if err != nil {
 		log.Fatalf("Error processing clusters: %v", err)
 	}
 
+	if *dendrogram != "" {
+		if *method == "average" || *method == "ward" {
+			log.Println("--dendrogram is only supported for the 'single' and 'complete' methods; skipping.")
+		} else if err := writeDendrogram(*dendrogram, labels, events); err != nil {
+			log.Fatalf("Error writing dendrogram: %v", err)
+		}
+	}
+
+	if *heights != "" {
+		if *method == "average" || *method == "ward" {
+			log.Println("--heights is only supported for the 'single' and 'complete' methods; skipping.")
+		} else if err := writeHeights(*heights, events); err != nil {
+			log.Fatalf("Error writing merge heights: %v", err)
+		}
+	}
+
 	if err := exportClusters(*output, clusters); err != nil {
 		log.Fatalf("Error exporting clusters: %v", err)
 	}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// linkagePair is a candidate merge in the priority queue, keyed by inter-cluster distance.
+type linkagePair struct {
+	a, b     int
+	distance float64
+}
+
+// linkagePairHeap is a min-heap of linkagePair ordered by ascending distance.
+type linkagePairHeap []*linkagePair
+
+func (h linkagePairHeap) Len() int            { return len(h) }
+func (h linkagePairHeap) Less(i, j int) bool  { return h[i].distance < h[j].distance }
+func (h linkagePairHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *linkagePairHeap) Push(x interface{}) { *h = append(*h, x.(*linkagePair)) }
+func (h *linkagePairHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	p := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return p
+}
+
+// readPairwiseMatrix reads a label1/label2/distance stream, interning each label into an
+// integer cluster ID, and accumulates the running sum and count of distances observed
+// between every pair of (initially singleton) clusters. Labels are returned in order of
+// first appearance so every label - even one that never meets the cutoff - gets an ID.
+func readPairwiseMatrix(inputPath string) (labels []string, sumDistances map[int]map[int]float64, pairCounts map[int]map[int]int, err error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer file.Close()
+
+	idByLabel := make(map[string]int)
+	sumDistances = make(map[int]map[int]float64)
+	pairCounts = make(map[int]map[int]int)
+
+	internLabel := func(label string) int {
+		if id, ok := idByLabel[label]; ok {
+			return id
+		}
+		id := len(labels)
+		idByLabel[label] = id
+		labels = append(labels, label)
+		sumDistances[id] = make(map[int]float64)
+		pairCounts[id] = make(map[int]int)
+		return id
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 3 {
+			continue // Skip lines that don't have enough parts
+		}
+
+		distance, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		id1 := internLabel(parts[0])
+		id2 := internLabel(parts[1])
+		if id1 == id2 {
+			continue
+		}
+
+		sumDistances[id1][id2] += distance
+		pairCounts[id1][id2]++
+		sumDistances[id2][id1] += distance
+		pairCounts[id2][id1]++
+	}
+
+	return labels, sumDistances, pairCounts, scanner.Err()
+}
+
+// Average linkage (UPGMA) clustering
+func getAverageLinkageClusters(inputPath string, cutOff float64, includeEqual bool) ([]clusterInfo, error) {
+	labels, sumDistances, pairCounts, err := readPairwiseMatrix(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[int][]string, len(labels))
+	for id, label := range labels {
+		members[id] = []string{label}
+	}
+
+	pq := &linkagePairHeap{}
+	heap.Init(pq)
+	for a, row := range sumDistances {
+		for b, sum := range row {
+			if a >= b {
+				continue
+			}
+			heap.Push(pq, &linkagePair{a: a, b: b, distance: sum / float64(pairCounts[a][b])})
+		}
+	}
+
+	for pq.Len() > 0 {
+		top := heap.Pop(pq).(*linkagePair)
+
+		// Skip entries referencing clusters already merged away.
+		if _, ok := members[top.a]; !ok {
+			continue
+		}
+		if _, ok := members[top.b]; !ok {
+			continue
+		}
+		if _, stillLinked := sumDistances[top.a][top.b]; !stillLinked {
+			continue
+		}
+
+		distance := sumDistances[top.a][top.b] / float64(pairCounts[top.a][top.b])
+		if includeEqual && distance > cutOff || !includeEqual && distance >= cutOff {
+			// A popped pair over the cutoff does not mean every remaining pair is: a merge can
+			// pull one pair's average up past the cutoff while a genuinely low, untouched pair
+			// still sits deeper in the heap. Skip this pair and keep draining instead of
+			// stopping early.
+			continue
+		}
+
+		mergeAverageLinkage(top.a, top.b, members, sumDistances, pairCounts, pq)
+	}
+
+	return finalizeClusters(members), nil
+}
+
+// mergeAverageLinkage merges cluster b into cluster a, combining the running sums and counts
+// against every other cluster z per sum[a][z] += sum[b][z], count[a][z] += count[b][z], then
+// re-queues the updated pair so its new average distance is considered on a later pop.
+func mergeAverageLinkage(a, b int, members map[int][]string, sumDistances map[int]map[int]float64, pairCounts map[int]map[int]int, pq *linkagePairHeap) {
+	for z, sum := range sumDistances[b] {
+		if z == a {
+			continue
+		}
+		sumDistances[a][z] += sum
+		pairCounts[a][z] += pairCounts[b][z]
+		sumDistances[z][a] = sumDistances[a][z]
+		pairCounts[z][a] = pairCounts[a][z]
+		delete(sumDistances[z], b)
+		delete(pairCounts[z], b)
+
+		heap.Push(pq, &linkagePair{a: a, b: z, distance: sumDistances[a][z] / float64(pairCounts[a][z])})
+	}
+
+	delete(sumDistances[a], b)
+	delete(pairCounts[a], b)
+	delete(sumDistances, b)
+	delete(pairCounts, b)
+
+	members[a] = append(members[a], members[b]...)
+	delete(members, b)
+}
+
+// Ward's method clustering
+func getWardLinkageClusters(inputPath string, cutOff float64, includeEqual bool) ([]clusterInfo, error) {
+	labels, sumDistances, pairCounts, err := readPairwiseMatrix(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[int][]string, len(labels))
+	sizes := make(map[int]int, len(labels))
+	distances := make(map[int]map[int]float64, len(labels))
+	for id, label := range labels {
+		members[id] = []string{label}
+		sizes[id] = 1
+		distances[id] = make(map[int]float64)
+	}
+	for a, row := range sumDistances {
+		for b, sum := range row {
+			distances[a][b] = sum / float64(pairCounts[a][b])
+		}
+	}
+
+	pq := &linkagePairHeap{}
+	heap.Init(pq)
+	for a, row := range distances {
+		for b, d := range row {
+			if a >= b {
+				continue
+			}
+			heap.Push(pq, &linkagePair{a: a, b: b, distance: d})
+		}
+	}
+
+	for pq.Len() > 0 {
+		top := heap.Pop(pq).(*linkagePair)
+
+		if _, ok := members[top.a]; !ok {
+			continue
+		}
+		if _, ok := members[top.b]; !ok {
+			continue
+		}
+		distance, stillLinked := distances[top.a][top.b]
+		if !stillLinked {
+			continue
+		}
+		if includeEqual && distance > cutOff || !includeEqual && distance >= cutOff {
+			// A popped pair over the cutoff does not mean every remaining pair is: a merge can
+			// pull one pair's distance up past the cutoff while a genuinely low, untouched pair
+			// still sits deeper in the heap. Skip this pair and keep draining instead of
+			// stopping early.
+			continue
+		}
+
+		mergeWardLinkage(top.a, top.b, distance, members, sizes, distances, pq)
+	}
+
+	return finalizeClusters(members), nil
+}
+
+// mergeWardLinkage merges cluster j into cluster i, updating every other cluster k's distance
+// to the merged cluster via the Lance-Williams recurrence:
+//
+//	d(i∪j,k) = ((n_i+n_k)*d(i,k) + (n_j+n_k)*d(j,k) - n_k*d(i,j)) / (n_i+n_j+n_k)
+//
+// The recurrence needs both d(i,k) and d(j,k); with the sparse `pairs` input format one side is
+// often missing. Rather than substitute the other side's value for it - which fabricates a
+// distance that was never observed - k is dropped as a neighbor of the merged cluster whenever
+// either side is unknown.
+func mergeWardLinkage(i, j int, dij float64, members map[int][]string, sizes map[int]int, distances map[int]map[int]float64, pq *linkagePairHeap) {
+	ni, nj := sizes[i], sizes[j]
+
+	neighbors := make(map[int]bool)
+	for k := range distances[i] {
+		if k != j {
+			neighbors[k] = true
+		}
+	}
+	for k := range distances[j] {
+		if k != i {
+			neighbors[k] = true
+		}
+	}
+
+	for k := range neighbors {
+		dik, okI := distances[i][k]
+		djk, okJ := distances[j][k]
+		delete(distances[i], k)
+		delete(distances[k], i)
+		delete(distances[k], j)
+
+		if !okI || !okJ {
+			continue
+		}
+
+		nk := sizes[k]
+		merged := ((float64(ni+nk))*dik + (float64(nj+nk))*djk - float64(nk)*dij) / float64(ni+nj+nk)
+
+		distances[i][k] = merged
+		distances[k][i] = merged
+
+		heap.Push(pq, &linkagePair{a: i, b: k, distance: merged})
+	}
+
+	delete(distances[i], j)
+	delete(distances, j)
+
+	sizes[i] = ni + nj
+	delete(sizes, j)
+
+	members[i] = append(members[i], members[j]...)
+	delete(members, j)
+}
+
+// finalizeClusters converts the surviving cluster-ID -> members map into a sequentially
+// renumbered []clusterInfo, the same shape produced by the streaming linkage functions.
+func finalizeClusters(members map[int][]string) []clusterInfo {
+	clusterMembers := make(map[int]map[string]bool, len(members))
+	for id, labels := range members {
+		set := make(map[string]bool, len(labels))
+		for _, label := range labels {
+			set[label] = true
+		}
+		clusterMembers[id] = set
+	}
+
+	clusters := buildClusterInfo(clusterMembers, make(map[string]int))
+	return reassignClusterIDs(clusters)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTempPairs writes a label1/label2/distance stream to a temp file and returns its path.
+func writeTempPairs(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pairs.txt")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing temp input: %v", err)
+	}
+	return path
+}
+
+// memberSets reassembles clusterInfo output into sets for order-independent comparison.
+func memberSets(clusters []clusterInfo) []map[string]bool {
+	sets := make([]map[string]bool, len(clusters))
+	for i, cluster := range clusters {
+		set := make(map[string]bool, len(cluster.Members))
+		for _, label := range cluster.Members {
+			set[label] = true
+		}
+		sets[i] = set
+	}
+	return sets
+}
+
+func containsSet(sets []map[string]bool, members ...string) bool {
+	for _, set := range sets {
+		if len(set) != len(members) {
+			continue
+		}
+		match := true
+		for _, m := range members {
+			if !set[m] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetAverageLinkageClusters(t *testing.T) {
+	path := writeTempPairs(t, "A B 1\nA C 3\nB C 5\n")
+
+	clusters, err := getAverageLinkageClusters(path, 2, true)
+	if err != nil {
+		t.Fatalf("getAverageLinkageClusters: %v", err)
+	}
+
+	sets := memberSets(clusters)
+	if !containsSet(sets, "A", "B") {
+		t.Errorf("expected {A,B} to merge at cutoff 2 (average of A-C,B-C is 4), got %v", sets)
+	}
+	if !containsSet(sets, "C") {
+		t.Errorf("expected C to remain a singleton, got %v", sets)
+	}
+}
+
+func TestGetWardLinkageClusters(t *testing.T) {
+	path := writeTempPairs(t, "A B 1\nA C 4\nB C 4\n")
+
+	clusters, err := getWardLinkageClusters(path, 10, true)
+	if err != nil {
+		t.Fatalf("getWardLinkageClusters: %v", err)
+	}
+
+	sets := memberSets(clusters)
+	if !containsSet(sets, "A", "B", "C") {
+		t.Errorf("expected A, B, and C to all merge under a generous cutoff, got %v", sets)
+	}
+}
+
+// TestGetAverageLinkageClustersDoesNotStopAtFirstOverCutoffPop guards against reintroducing the
+// bug where popping a pair whose recomputed live distance exceeded the cutoff ended the whole
+// loop. A-Z starts low, but merging {A,B} pulls the average distance to Z up past the cutoff
+// (to 51); that must not stop C-D, an untouched pair with a genuinely low distance sitting
+// further down the heap, from being merged too.
+func TestGetAverageLinkageClustersDoesNotStopAtFirstOverCutoffPop(t *testing.T) {
+	path := writeTempPairs(t, "A Z 2\nB Z 100\nA B 1\nC D 5\n")
+
+	clusters, err := getAverageLinkageClusters(path, 50, true)
+	if err != nil {
+		t.Fatalf("getAverageLinkageClusters: %v", err)
+	}
+
+	sets := memberSets(clusters)
+	if !containsSet(sets, "A", "B") {
+		t.Errorf("expected {A,B} to merge, got %v", sets)
+	}
+	if !containsSet(sets, "C", "D") {
+		t.Errorf("expected {C,D} to merge even though {A,B}-Z later exceeds the cutoff, got %v", sets)
+	}
+}
+
+// TestGetWardLinkageClustersDoesNotStopAtFirstOverCutoffPop is the Ward-linkage counterpart of
+// TestGetAverageLinkageClustersDoesNotStopAtFirstOverCutoffPop: the same input must not let the
+// post-merge {A,B}-Z distance crossing the cutoff prevent the untouched, genuinely low C-D pair
+// from merging.
+func TestGetWardLinkageClustersDoesNotStopAtFirstOverCutoffPop(t *testing.T) {
+	path := writeTempPairs(t, "A Z 2\nB Z 100\nA B 1\nC D 5\n")
+
+	clusters, err := getWardLinkageClusters(path, 50, true)
+	if err != nil {
+		t.Fatalf("getWardLinkageClusters: %v", err)
+	}
+
+	sets := memberSets(clusters)
+	if !containsSet(sets, "A", "B") {
+		t.Errorf("expected {A,B} to merge, got %v", sets)
+	}
+	if !containsSet(sets, "C", "D") {
+		t.Errorf("expected {C,D} to merge even though {A,B}-Z later exceeds the cutoff, got %v", sets)
+	}
+}
+
+// TestMergeWardLinkageDoesNotFabricateMissingDistance guards against reintroducing the bug
+// where a neighbor k with a distance recorded to only one of the two merging clusters had its
+// missing side silently set equal to the known side. With A-C never observed, Ward must not
+// merge {A,B} with C - it should leave C a singleton until a real A-C or merged-cluster-C
+// distance is seen.
+func TestMergeWardLinkageDoesNotFabricateMissingDistance(t *testing.T) {
+	path := writeTempPairs(t, "A B 1\nB C 5\n")
+
+	clusters, err := getWardLinkageClusters(path, 100, true)
+	if err != nil {
+		t.Fatalf("getWardLinkageClusters: %v", err)
+	}
+
+	sets := memberSets(clusters)
+	if !containsSet(sets, "A", "B") {
+		t.Errorf("expected A and B to merge, got %v", sets)
+	}
+	if !containsSet(sets, "C") {
+		t.Errorf("expected C to remain a singleton since A-C was never observed, got %v", sets)
+	}
+}
@@ -0,0 +1,56 @@
+package unionfind
+
+import "testing"
+
+func TestInternIsIdempotent(t *testing.T) {
+	uf := New()
+	a := uf.Intern("A")
+	aAgain := uf.Intern("A")
+	if a != aAgain {
+		t.Errorf("Intern(%q) returned %d, then %d on a second call", "A", a, aAgain)
+	}
+	if uf.Len() != 1 {
+		t.Errorf("expected 1 interned label, got %d", uf.Len())
+	}
+}
+
+func TestUnionFindMergesSets(t *testing.T) {
+	uf := New()
+	a, b, c, d := uf.Intern("A"), uf.Intern("B"), uf.Intern("C"), uf.Intern("D")
+
+	if uf.Find(a) == uf.Find(c) {
+		t.Fatalf("A and C should start in different sets")
+	}
+
+	uf.Union(a, b)
+	uf.Union(c, d)
+	if uf.Find(a) != uf.Find(b) {
+		t.Errorf("A and B should be in the same set after Union")
+	}
+	if uf.Find(a) == uf.Find(c) {
+		t.Errorf("A and C should still be in different sets")
+	}
+
+	root := uf.Union(b, c)
+	if uf.Find(a) != uf.Find(d) {
+		t.Errorf("all four labels should be in the same set after the second Union")
+	}
+	if uf.Size(root) != 4 {
+		t.Errorf("expected merged set size 4, got %d", uf.Size(root))
+	}
+}
+
+func TestUnionOfSameSetIsNoOp(t *testing.T) {
+	uf := New()
+	a, b := uf.Intern("A"), uf.Intern("B")
+	uf.Union(a, b)
+	sizeBefore := uf.Size(a)
+
+	root := uf.Union(a, b)
+	if root != uf.Find(a) {
+		t.Errorf("Union of an already-merged pair should return the existing root")
+	}
+	if uf.Size(a) != sizeBefore {
+		t.Errorf("Union of an already-merged pair should not change set size, got %d want %d", uf.Size(a), sizeBefore)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// convertPhylipToPairs reads a PHYLIP square or lower-triangle distance matrix and writes an
+// equivalent "label1 label2 distance" stream to a temporary file, so the rest of the pipeline
+// (getSingleLinkageClusters, getCompleteLinkageClusters, and the matrix-fed linkage methods)
+// can consume it without any matrix-specific code of their own.
+//
+// The first line holds the taxa count N. Each of the following N lines starts with a taxon
+// label followed by its distances: N floats for a square matrix, or i floats for row i of a
+// lower-triangle matrix (the distances to the i-1 preceding taxa, plus the zero diagonal).
+func convertPhylipToPairs(inputPath string, lowerTriangle bool) (string, error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	if !scanner.Scan() {
+		return "", fmt.Errorf("phylip input is empty")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil {
+		return "", fmt.Errorf("invalid taxa count on first line: %w", err)
+	}
+
+	labels := make([]string, 0, n)
+	rows := make([][]float64, 0, n)
+	for i := 1; i <= n; i++ {
+		if !scanner.Scan() {
+			return "", fmt.Errorf("expected %d taxa, found %d", n, i-1)
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			return "", fmt.Errorf("malformed row %d: expected a label followed by distances", i)
+		}
+		label, values := fields[0], fields[1:]
+
+		expected := n
+		if lowerTriangle {
+			expected = i
+		}
+		if len(values) != expected {
+			return "", fmt.Errorf("row %d: expected %d distances, found %d", i, expected, len(values))
+		}
+
+		row := make([]float64, len(values))
+		for j, v := range values {
+			d, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return "", err
+			}
+			row[j] = d
+		}
+
+		labels = append(labels, label)
+		rows = append(rows, row)
+	}
+
+	tmp, err := os.CreateTemp("", "goclust-phylip-*.tsv")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+
+	// Both formats carry the distance between taxa i and j (j < i) in row i at index j,
+	// so a single lower-triangle-style pass dedupes the square matrix's redundant upper half.
+	writer := bufio.NewWriter(tmp)
+	for i, row := range rows {
+		for j := 0; j < i; j++ {
+			if _, err := fmt.Fprintf(writer, "%s\t%s\t%g\n", labels[i], labels[j], row[j]); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
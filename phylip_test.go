@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// readConvertedPairs runs convertPhylipToPairs and returns the resulting pairs file's content,
+// removing the temp file it created.
+func readConvertedPairs(t *testing.T, phylipPath string, lowerTriangle bool) string {
+	t.Helper()
+	pairsPath, err := convertPhylipToPairs(phylipPath, lowerTriangle)
+	if err != nil {
+		t.Fatalf("convertPhylipToPairs: %v", err)
+	}
+	defer os.Remove(pairsPath)
+
+	contents, err := os.ReadFile(pairsPath)
+	if err != nil {
+		t.Fatalf("reading converted pairs file: %v", err)
+	}
+	return string(contents)
+}
+
+func TestConvertPhylipToPairsSquare(t *testing.T) {
+	path := writeTempPairs(t, "3\nA 0 1 2\nB 1 0 3\nC 2 3 0\n")
+
+	got := readConvertedPairs(t, path, false)
+	want := "B\tA\t1\nC\tA\t2\nC\tB\t3\n"
+	if got != want {
+		t.Errorf("got pairs:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvertPhylipToPairsLowerTriangle(t *testing.T) {
+	path := writeTempPairs(t, "3\nA 0\nB 1 0\nC 2 3 0\n")
+
+	got := readConvertedPairs(t, path, true)
+	want := "B\tA\t1\nC\tA\t2\nC\tB\t3\n"
+	if got != want {
+		t.Errorf("got pairs:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvertPhylipToPairsEmptyInput(t *testing.T) {
+	path := writeTempPairs(t, "")
+
+	if _, err := convertPhylipToPairs(path, false); err == nil {
+		t.Fatal("expected an error for an empty phylip input, got nil")
+	}
+}
+
+func TestConvertPhylipToPairsInvalidTaxaCount(t *testing.T) {
+	path := writeTempPairs(t, "not-a-number\nA 0\n")
+
+	_, err := convertPhylipToPairs(path, false)
+	if err == nil || !strings.Contains(err.Error(), "invalid taxa count") {
+		t.Fatalf("expected an invalid-taxa-count error, got %v", err)
+	}
+}
+
+func TestConvertPhylipToPairsTooFewRows(t *testing.T) {
+	path := writeTempPairs(t, "3\nA 0 1 2\nB 1 0 3\n")
+
+	_, err := convertPhylipToPairs(path, false)
+	if err == nil || !strings.Contains(err.Error(), "expected 3 taxa") {
+		t.Fatalf("expected a too-few-rows error, got %v", err)
+	}
+}
+
+func TestConvertPhylipToPairsMalformedRow(t *testing.T) {
+	path := writeTempPairs(t, "2\nA\nB 1 0\n")
+
+	_, err := convertPhylipToPairs(path, false)
+	if err == nil || !strings.Contains(err.Error(), "malformed row") {
+		t.Fatalf("expected a malformed-row error, got %v", err)
+	}
+}
+
+func TestConvertPhylipToPairsWrongFieldCount(t *testing.T) {
+	path := writeTempPairs(t, "2\nA 0\nB 1 0\n")
+
+	_, err := convertPhylipToPairs(path, false)
+	if err == nil || !strings.Contains(err.Error(), "expected 2 distances, found 1") {
+		t.Fatalf("expected a wrong-field-count error, got %v", err)
+	}
+}